@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	broadcastInitialBackoff = time.Second
+	broadcastMaxBackoff     = 30 * time.Second
+)
+
+// BroadcastManager runs (at most) one external Sink worker at a time,
+// feeding it frames from the same Broadcaster that serves /stream
+// clients. It restarts the sink with exponential backoff on failure so a
+// flaky RTMP ingest doesn't need babysitting.
+type BroadcastManager struct {
+	broadcaster *Broadcaster
+
+	mu     sync.Mutex
+	url    string
+	cancel context.CancelFunc
+}
+
+func newBroadcastManager(broadcaster *Broadcaster) *BroadcastManager {
+	return &BroadcastManager{broadcaster: broadcaster}
+}
+
+// Start begins broadcasting to url. It is an error to call Start while a
+// broadcast is already active; call Stop first.
+func (m *BroadcastManager) Start(url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel != nil {
+		return fmt.Errorf("broadcast already active to %s", m.url)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.url = url
+
+	go m.run(ctx, url)
+
+	return nil
+}
+
+// Stop halts the active broadcast, if any.
+func (m *BroadcastManager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cancel == nil {
+		return fmt.Errorf("no broadcast is active")
+	}
+
+	m.cancel()
+	m.cancel = nil
+	m.url = ""
+
+	return nil
+}
+
+// run drives a single broadcast session: subscribe once to the
+// broadcaster, then keep (re)starting the ffmpeg sink and pumping frames
+// into it until ctx is cancelled.
+func (m *BroadcastManager) run(ctx context.Context, url string) {
+	id, frames := m.broadcaster.Subscribe()
+	defer m.broadcaster.Unsubscribe(id)
+
+	backoff := broadcastInitialBackoff
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		sink, err := newFFmpegSink(url)
+		if err != nil {
+			logJSON("error", fmt.Sprintf("Failed to start broadcast sink: %v", err))
+			writeJSON("broadcast_status", map[string]interface{}{
+				"status": "error",
+				"url":    url,
+				"error":  err.Error(),
+			})
+			if !sleepOrDone(ctx, backoff) {
+				return
+			}
+			backoff = nextBroadcastBackoff(backoff)
+			continue
+		}
+
+		logJSON("info", fmt.Sprintf("Broadcast started to %s", url))
+		writeJSON("broadcast_status", map[string]interface{}{
+			"status": "started",
+			"url":    url,
+		})
+		backoff = broadcastInitialBackoff
+
+		pumpErr := pumpFrames(ctx, frames, sink)
+		sink.Close()
+
+		if ctx.Err() != nil {
+			writeJSON("broadcast_status", map[string]interface{}{
+				"status": "stopped",
+				"url":    url,
+			})
+			return
+		}
+
+		logJSON("warning", fmt.Sprintf("Broadcast sink failed, restarting: %v", pumpErr))
+		writeJSON("broadcast_status", map[string]interface{}{
+			"status": "restarting",
+			"url":    url,
+			"error":  pumpErr.Error(),
+		})
+
+		if !sleepOrDone(ctx, backoff) {
+			return
+		}
+		backoff = nextBroadcastBackoff(backoff)
+	}
+}
+
+// pumpFrames writes frames to sink until ctx is cancelled or a write fails.
+func pumpFrames(ctx context.Context, frames <-chan []byte, sink Sink) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case frame, ok := <-frames:
+			if !ok {
+				return fmt.Errorf("broadcaster subscription closed")
+			}
+			if err := sink.Write(frame); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sleepOrDone waits for d, returning false early (without sleeping the
+// full duration) if ctx is cancelled first.
+func sleepOrDone(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func nextBroadcastBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > broadcastMaxBackoff {
+		return broadcastMaxBackoff
+	}
+	return d
+}
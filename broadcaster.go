@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// subscriberBufSize is the number of frames buffered per subscriber before
+// the oldest queued frame is dropped in favor of the newest one.
+const subscriberBufSize = 3
+
+// subscriber holds the per-client frame channel and bookkeeping used by
+// Broadcaster to fan frames out without letting a slow client block capture.
+type subscriber struct {
+	ch      chan []byte
+	dropped uint64
+}
+
+// Broadcaster fans frames out to any number of subscribers, each with its
+// own bounded channel. Publish never blocks: a subscriber that falls behind
+// has its oldest buffered frame dropped to make room for the newest one.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[uint64]*subscriber
+	nextID      uint64
+}
+
+// NewBroadcaster creates an empty Broadcaster ready to accept subscribers.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[uint64]*subscriber),
+	}
+}
+
+// Subscribe registers a new client and returns its id (for Unsubscribe) and
+// the channel it should read frames from.
+func (b *Broadcaster) Subscribe() (uint64, <-chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	sub := &subscriber{ch: make(chan []byte, subscriberBufSize)}
+	b.subscribers[id] = sub
+
+	return id, sub.ch
+}
+
+// Unsubscribe removes a client. Safe to call more than once for the same id.
+func (b *Broadcaster) Unsubscribe(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if sub, ok := b.subscribers[id]; ok {
+		close(sub.ch)
+		delete(b.subscribers, id)
+	}
+}
+
+// Count returns the number of currently subscribed clients.
+func (b *Broadcaster) Count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Publish fans frame out to every subscriber without blocking. A subscriber
+// whose buffer is full has its oldest frame dropped so capture never stalls
+// waiting on a slow HTTP client.
+func (b *Broadcaster) Publish(frame []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, sub := range b.subscribers {
+		select {
+		case sub.ch <- frame:
+		default:
+			// Buffer full: drop the oldest queued frame and try again.
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- frame:
+			default:
+				// Still couldn't enqueue (rare race with a concurrent
+				// reader); count it as dropped rather than blocking.
+				sub.dropped++
+				logJSON("debug", fmt.Sprintf("Dropped frame for subscriber %d (total dropped: %d)", id, sub.dropped))
+				continue
+			}
+			sub.dropped++
+			logJSON("debug", fmt.Sprintf("Dropped frame for subscriber %d (total dropped: %d)", id, sub.dropped))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcasterDropsOldestFrameWhenSubscriberBufferIsFull(t *testing.T) {
+	b := NewBroadcaster()
+	id, frames := b.Subscribe()
+	defer b.Unsubscribe(id)
+
+	// Fill the subscriber's buffer, then publish one more: frame 0 (the
+	// oldest) should be dropped in favor of the new frame, leaving frames
+	// 1..subscriberBufSize in the channel.
+	for i := 0; i < subscriberBufSize+1; i++ {
+		b.Publish([]byte{byte(i)})
+	}
+
+	for i := 1; i <= subscriberBufSize; i++ {
+		select {
+		case frame := <-frames:
+			if len(frame) != 1 || frame[0] != byte(i) {
+				t.Fatalf("frame %d: got %v, want [%d]", i, frame, i)
+			}
+		default:
+			t.Fatalf("frame %d: expected buffered frame, channel was empty", i)
+		}
+	}
+
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected extra frame %v after draining the buffer", frame)
+	default:
+	}
+}
+
+func TestBroadcasterPublishNeverBlocksWithNoSubscribers(t *testing.T) {
+	b := NewBroadcaster()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Publish([]byte("frame"))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked with no subscribers")
+	}
+}
+
+func TestBroadcasterUnsubscribeClosesChannelAndIsIdempotent(t *testing.T) {
+	b := NewBroadcaster()
+	id, frames := b.Subscribe()
+
+	b.Unsubscribe(id)
+	b.Unsubscribe(id) // must not panic (double close) or block
+
+	if _, ok := <-frames; ok {
+		t.Fatal("expected frames channel to be closed after Unsubscribe")
+	}
+
+	if got := b.Count(); got != 0 {
+		t.Fatalf("Count() = %d, want 0", got)
+	}
+}
+
+// TestBroadcasterConcurrentSubscribePublishUnsubscribe exercises the
+// Broadcaster the way the real capture/HTTP goroutines do: one publisher
+// racing against many subscribers joining, reading a few frames, and
+// leaving, all concurrently. It's a race-detector target (run with
+// `go test -race`) more than a behavioral assertion, but it also checks
+// that Publish/Subscribe/Unsubscribe don't deadlock under contention.
+func TestBroadcasterConcurrentSubscribePublishUnsubscribe(t *testing.T) {
+	b := NewBroadcaster()
+
+	stopPublishing := make(chan struct{})
+	var publishWG sync.WaitGroup
+	publishWG.Add(1)
+	go func() {
+		defer publishWG.Done()
+		frame := []byte("frame")
+		for {
+			select {
+			case <-stopPublishing:
+				return
+			default:
+				b.Publish(frame)
+			}
+		}
+	}()
+
+	var clientsWG sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		clientsWG.Add(1)
+		go func() {
+			defer clientsWG.Done()
+			id, frames := b.Subscribe()
+			for j := 0; j < 5; j++ {
+				<-frames
+			}
+			b.Unsubscribe(id)
+		}()
+	}
+
+	clientsWG.Wait()
+	close(stopPublishing)
+	publishWG.Wait()
+
+	if got := b.Count(); got != 0 {
+		t.Fatalf("Count() = %d after all clients unsubscribed, want 0", got)
+	}
+}
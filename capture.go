@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	stdgif "image/gif"
+	stdjpeg "image/jpeg"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ericpauley/go-quantize/quantize"
+)
+
+// captureBurst saves n sequential full-resolution JPEGs from frameBuffer to
+// dir, spaced intervalMs apart, and returns the number of frames actually
+// written (fewer than n if no frame was available on some tick).
+func captureBurst(frameBuffer *FrameBuffer, n, intervalMs int, dir string) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create burst directory: %w", err)
+	}
+
+	saved := 0
+	for i := 0; i < n; i++ {
+		frame := frameBuffer.GetFull()
+		if frame == nil {
+			logJSON("warning", fmt.Sprintf("No frame available for burst capture (frame %d/%d)", i+1, n))
+		} else {
+			name := filepath.Join(dir, fmt.Sprintf("frame_%04d.jpeg", i))
+			if err := os.WriteFile(name, frame, 0644); err != nil {
+				return saved, fmt.Errorf("failed to write burst frame %d: %w", i, err)
+			}
+			saved++
+		}
+
+		if i < n-1 {
+			time.Sleep(time.Duration(intervalMs) * time.Millisecond)
+		}
+	}
+
+	return saved, nil
+}
+
+// captureGIF captures n full-resolution frames intervalMs apart, palette
+// quantizes each with a median-cut quantizer, and encodes them as a single
+// animated GIF at path. It returns the number of frames included.
+func captureGIF(frameBuffer *FrameBuffer, n, intervalMs int, path string) (int, error) {
+	delay := intervalMs / 10 // gif.GIF.Delay is in 1/100s units
+	if delay <= 0 {
+		delay = 1
+	}
+
+	var quantizer quantize.MedianCutQuantizer
+	anim := &stdgif.GIF{}
+
+	for i := 0; i < n; i++ {
+		frame := frameBuffer.GetFull()
+		if frame == nil {
+			logJSON("warning", fmt.Sprintf("No frame available for gif capture (frame %d/%d)", i+1, n))
+		} else {
+			img, err := stdjpeg.Decode(bytes.NewReader(frame))
+			if err != nil {
+				return len(anim.Image), fmt.Errorf("failed to decode frame %d for gif: %w", i, err)
+			}
+
+			palette := quantizer.Quantize(make(color.Palette, 0, 256), img)
+			paletted := image.NewPaletted(img.Bounds(), palette)
+			draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, img.Bounds().Min)
+
+			anim.Image = append(anim.Image, paletted)
+			anim.Delay = append(anim.Delay, delay)
+		}
+
+		if i < n-1 {
+			time.Sleep(time.Duration(intervalMs) * time.Millisecond)
+		}
+	}
+
+	if len(anim.Image) == 0 {
+		return 0, fmt.Errorf("no frames captured for gif")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create gif file: %w", err)
+	}
+	defer f.Close()
+
+	if err := stdgif.EncodeAll(f, anim); err != nil {
+		return 0, fmt.Errorf("failed to encode gif: %w", err)
+	}
+
+	return len(anim.Image), nil
+}
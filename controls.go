@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// controlSlugs maps the handful of V4L2 controls USB webcams commonly
+// expose to short, human-readable names, so the Elixir supervisor can
+// refer to e.g. "brightness" instead of hardcoding numeric control IDs
+// that vary subtly between camera models.
+var controlSlugs = map[uint32]string{
+	v4l2.CtrlBrightness:              "brightness",
+	v4l2.CtrlContrast:                "contrast",
+	v4l2.CtrlSaturation:              "saturation",
+	v4l2.CtrlHue:                     "hue",
+	v4l2.CtrlAutoWhiteBalance:        "auto_white_balance",
+	v4l2.CtrlWhiteBalanceTemperature: "white_balance_temperature",
+	v4l2.CtrlGamma:                   "gamma",
+	v4l2.CtrlGain:                    "gain",
+	v4l2.CtrlPowerlineFrequency:      "power_line_frequency",
+	v4l2.CtrlSharpness:               "sharpness",
+	v4l2.CtrlBacklightCompensation:   "backlight_compensation",
+	v4l2.CtrlCameraExposureAuto:      "exposure_auto",
+	v4l2.CtrlCameraExposureAbsolute:  "exposure_absolute",
+}
+
+// controlMenuItem is one entry of a menu-type control, as enumerated via
+// VIDIOC_QUERYMENU.
+type controlMenuItem struct {
+	Index int32  `json:"index"`
+	Label string `json:"label"`
+}
+
+// controlInfo is the self-describing, JSON-friendly view of a single V4L2
+// control that getControlsJSON reports: named, typed, and (for menu
+// controls) with its menu items enumerated.
+type controlInfo struct {
+	ID      uint32            `json:"id"`
+	Slug    string            `json:"slug,omitempty"`
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Value   int32             `json:"value"`
+	Minimum int32             `json:"minimum"`
+	Maximum int32             `json:"maximum"`
+	Step    int32             `json:"step"`
+	Default int32             `json:"default"`
+	Menu    []controlMenuItem `json:"menu,omitempty"`
+}
+
+// controlTypeName maps go4vl's raw control type to the small vocabulary
+// ("int", "bool", "menu", "button") the port protocol exposes.
+func controlTypeName(t v4l2.CtrlType) string {
+	switch t {
+	case v4l2.CtrlTypeInt:
+		return "int"
+	case v4l2.CtrlTypeBool:
+		return "bool"
+	case v4l2.CtrlTypeMenu, v4l2.CtrlTypeIntegerMenu:
+		return "menu"
+	case v4l2.CtrlTypeButton:
+		return "button"
+	default:
+		return "unknown"
+	}
+}
+
+// buildControlInfo converts a raw v4l2.Control (already queried for its
+// current value) into the structured, self-describing form, enumerating
+// menu items for menu-type controls.
+func buildControlInfo(ctrl v4l2.Control) controlInfo {
+	info := controlInfo{
+		ID:      ctrl.ID,
+		Slug:    controlSlugs[ctrl.ID],
+		Name:    ctrl.Name,
+		Type:    controlTypeName(ctrl.Type),
+		Value:   ctrl.Value,
+		Minimum: ctrl.Minimum,
+		Maximum: ctrl.Maximum,
+		Step:    ctrl.Step,
+		Default: ctrl.Default,
+	}
+
+	if info.Type == "menu" {
+		info.Menu = queryControlMenu(ctrl)
+	}
+
+	return info
+}
+
+// queryControlMenu enumerates the menu items of a menu-type control via
+// VIDIOC_QUERYMENU.
+func queryControlMenu(ctrl v4l2.Control) []controlMenuItem {
+	menuItems, err := ctrl.GetMenuItems()
+	if err != nil {
+		logJSON("warning", fmt.Sprintf("Failed to query menu items for control %d (%s): %v", ctrl.ID, ctrl.Name, err))
+		return nil
+	}
+
+	items := make([]controlMenuItem, 0, len(menuItems))
+	for _, mi := range menuItems {
+		items = append(items, controlMenuItem{Index: int32(mi.Index), Label: mi.Name})
+	}
+
+	return items
+}
+
+// getControlsJSON reports every queryable control on the open device as a
+// structured, self-describing catalog: typed, named where a slug is
+// known, and with menu items enumerated for menu controls.
+func getControlsJSON() {
+	if cam == nil {
+		logJSON("error", "No device is currently open")
+		return
+	}
+
+	// First get all available controls (metadata)
+	controls, err := cam.QueryAllControls()
+	if err != nil {
+		logJSON("error", fmt.Sprintf("Failed to query controls: %v", err))
+		return
+	}
+
+	// Now query each control individually to get its current value
+	controlsWithValues := make([]controlInfo, 0, len(controls))
+	for _, ctrl := range controls {
+		// Get the current value for this control
+		currentCtrl, err := cam.GetControl(ctrl.ID)
+		if err != nil {
+			// Skip control class headers and other unreadable controls (permission denied)
+			// These are organizational groupings like "User Controls" or "Camera Controls"
+			if strings.Contains(err.Error(), "permission denied") {
+				logJSON("debug", fmt.Sprintf("Skipping control class header: %d (%s)", ctrl.ID, ctrl.Name))
+				continue
+			}
+			// For other errors, log warning and use original control info
+			logJSON("warning", fmt.Sprintf("Failed to get current value for control %d (%s): %v", ctrl.ID, ctrl.Name, err))
+			controlsWithValues = append(controlsWithValues, buildControlInfo(ctrl))
+		} else {
+			controlsWithValues = append(controlsWithValues, buildControlInfo(currentCtrl))
+		}
+	}
+
+	// Output controls as JSON with type field
+	writeJSON("controls", map[string]interface{}{
+		"data": controlsWithValues,
+	})
+}
+
+// controlIDForSlug resolves a named control (e.g. "brightness") to its
+// numeric V4L2 control ID.
+func controlIDForSlug(slug string) (uint32, bool) {
+	for id, s := range controlSlugs {
+		if s == slug {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// setControlByName resolves slug to a control ID and sets it to valueStr,
+// which for menu controls may be either the integer menu index or the
+// menu item's string label.
+func setControlByName(slug string, valueStr string) {
+	if cam == nil {
+		logJSON("error", "No device is currently open")
+		writeJSON("set_control_response", map[string]interface{}{
+			"status": "error",
+			"error":  "no device is currently open",
+		})
+		return
+	}
+
+	controlID, ok := controlIDForSlug(slug)
+	if !ok {
+		logJSON("error", fmt.Sprintf("Unknown control name: %s", slug))
+		writeJSON("set_control_response", map[string]interface{}{
+			"status": "error",
+			"error":  fmt.Sprintf("unknown control name: %s", slug),
+		})
+		return
+	}
+
+	ctrl, err := cam.GetControl(controlID)
+	if err != nil {
+		logJSON("error", fmt.Sprintf("Failed to query control %s (%d): %v", slug, controlID, err))
+		writeJSON("set_control_response", map[string]interface{}{
+			"status": "error",
+			"error":  fmt.Sprintf("failed to query control: %v", err),
+			"id":     controlID,
+		})
+		return
+	}
+
+	value, err := resolveControlValue(ctrl, valueStr)
+	if err != nil {
+		logJSON("error", fmt.Sprintf("Invalid value %q for control %s: %v", valueStr, slug, err))
+		writeJSON("set_control_response", map[string]interface{}{
+			"status": "error",
+			"error":  err.Error(),
+			"id":     controlID,
+		})
+		return
+	}
+
+	applyControlValue(controlID, value)
+}
+
+// resolveControlValue parses valueStr for ctrl. Menu controls accept
+// either an integer menu index or the menu item's string label; every
+// other control type expects a plain integer.
+func resolveControlValue(ctrl v4l2.Control, valueStr string) (int32, error) {
+	if controlTypeName(ctrl.Type) != "menu" {
+		value, err := strconv.ParseInt(valueStr, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid control value: %s", valueStr)
+		}
+		return int32(value), nil
+	}
+
+	// Menu control: accept a numeric index directly if it parses...
+	if index, err := strconv.ParseInt(valueStr, 10, 32); err == nil {
+		return int32(index), nil
+	}
+
+	// ...otherwise resolve the string label against the menu.
+	for _, item := range queryControlMenu(ctrl) {
+		if strings.EqualFold(item.Label, valueStr) {
+			return item.Index, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown menu label %q for control %s", valueStr, ctrl.Name)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// ffmpegSink re-encodes the MJPEG frame stream to an external RTMP/HLS
+// target by piping raw frames into an ffmpeg subprocess.
+type ffmpegSink struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// newFFmpegSink starts an ffmpeg subprocess that reads MJPEG frames on
+// stdin and pushes an H.264 stream to url (e.g. an RTMP ingest URL, or an
+// .m3u8 path for on-disk HLS segmenting).
+func newFFmpegSink(url string) (*ffmpegSink, error) {
+	cmd := exec.Command("ffmpeg",
+		"-loglevel", "warning",
+		"-f", "mjpeg",
+		"-i", "-",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-f", "flv",
+		url,
+	)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ffmpeg stdin pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ffmpeg stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	go logFFmpegStderr(stderr)
+
+	return &ffmpegSink{cmd: cmd, stdin: stdin}, nil
+}
+
+func (s *ffmpegSink) Write(frame []byte) error {
+	_, err := s.stdin.Write(frame)
+	return err
+}
+
+// Close stops feeding frames and waits for ffmpeg to exit.
+func (s *ffmpegSink) Close() error {
+	s.stdin.Close()
+	return s.cmd.Wait()
+}
+
+// logFFmpegStderr forwards ffmpeg's stderr output line by line via
+// logJSON so it shows up alongside the rest of the streamer's logs.
+func logFFmpegStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logJSON("debug", fmt.Sprintf("ffmpeg: %s", scanner.Text()))
+	}
+}
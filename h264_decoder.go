@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/asticode/go-astiav"
+)
+
+// h264AnnexBStartCode precedes every NALU handed to the decoder. RTP
+// depacketization strips any framing, so the caller is responsible for
+// reassembling Annex-B (see annexBFromNALUs) before calling decode.
+var h264AnnexBStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// h264Decoder wraps an FFmpeg (via go-astiav) H.264 decoder, producing a
+// decoded image for each access unit that completes a frame. Access units
+// must already be Annex-B framed, with SPS/PPS NALUs included ahead of the
+// first IDR slice the decoder is expected to recognize.
+type h264Decoder struct {
+	codecCtx *astiav.CodecContext
+	pkt      *astiav.Packet
+	frame    *astiav.Frame
+}
+
+func newH264Decoder() (*h264Decoder, error) {
+	codec := astiav.FindDecoder(astiav.CodecIDH264)
+	if codec == nil {
+		return nil, fmt.Errorf("h264 decoder not available in this ffmpeg build")
+	}
+
+	codecCtx := astiav.AllocCodecContext(codec)
+	if codecCtx == nil {
+		return nil, fmt.Errorf("failed to allocate h264 codec context")
+	}
+
+	if err := codecCtx.Open(codec, nil); err != nil {
+		codecCtx.Free()
+		return nil, fmt.Errorf("failed to open h264 codec: %w", err)
+	}
+
+	return &h264Decoder{
+		codecCtx: codecCtx,
+		pkt:      astiav.AllocPacket(),
+		frame:    astiav.AllocFrame(),
+	}, nil
+}
+
+// annexBFromNALUs concatenates the NALUs of one access unit into a single
+// Annex-B buffer, prepending a start code to each, so the decoder can find
+// NALU boundaries the way it would reading straight off the wire.
+func annexBFromNALUs(nalus [][]byte) []byte {
+	size := 0
+	for _, nalu := range nalus {
+		size += len(h264AnnexBStartCode) + len(nalu)
+	}
+
+	buf := make([]byte, 0, size)
+	for _, nalu := range nalus {
+		buf = append(buf, h264AnnexBStartCode...)
+		buf = append(buf, nalu...)
+	}
+	return buf
+}
+
+// decode feeds one Annex-B access unit into the decoder and returns the
+// decoded image, or nil if the access unit didn't produce a displayable
+// frame (e.g. it was SPS/PPS-only, or the decoder hasn't seen its first
+// keyframe yet). Only ErrEagain/ErrEof from the codec are treated as "not
+// enough data yet"; any other error is returned so a genuinely broken
+// stream doesn't fail silently forever.
+func (d *h264Decoder) decode(accessUnit []byte) (image.Image, error) {
+	if err := d.pkt.FromData(accessUnit); err != nil {
+		return nil, fmt.Errorf("failed to wrap h264 access unit in packet: %w", err)
+	}
+	defer d.pkt.Unref()
+
+	if err := d.codecCtx.SendPacket(d.pkt); err != nil {
+		if errors.Is(err, astiav.ErrEagain) || errors.Is(err, astiav.ErrEof) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("h264 decoder rejected access unit: %w", err)
+	}
+
+	if err := d.codecCtx.ReceiveFrame(d.frame); err != nil {
+		if errors.Is(err, astiav.ErrEagain) || errors.Is(err, astiav.ErrEof) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("h264 decoder failed to produce a frame: %w", err)
+	}
+	defer d.frame.Unref()
+
+	dst, err := d.frame.Data().GuessImageFormat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine decoded frame's image format: %w", err)
+	}
+	if err := d.frame.Data().ToImage(dst); err != nil {
+		return nil, fmt.Errorf("failed to convert decoded frame to image: %w", err)
+	}
+
+	return dst, nil
+}
+
+func (d *h264Decoder) close() {
+	if d.frame != nil {
+		d.frame.Free()
+	}
+	if d.pkt != nil {
+		d.pkt.Free()
+	}
+	if d.codecCtx != nil {
+		d.codecCtx.Free()
+	}
+}
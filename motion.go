@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	stdjpeg "image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// motionBlockSize is the edge length, in SD-resolution pixels, of each
+	// block the motion detector compares independently.
+	motionBlockSize = 16
+
+	// motionEMAAlpha weights how quickly the running reference grayscale
+	// follows gradual lighting changes versus a sudden motion event.
+	motionEMAAlpha = 0.05
+
+	// motionActiveWindow is how long after a trigger the detector is still
+	// considered "active" for the purposes of adaptive frame skipping.
+	motionActiveWindow = 5 * time.Second
+
+	motionSnapshotDir      = "motion_snapshots"
+	motionSnapshotMaxFiles = 200
+)
+
+// motionRegion is a single changed block, reported in motion_event messages.
+type motionRegion struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// MotionDetector runs a running exponential-average block-difference motion
+// detector over the downscaled SD frame. It is enabled/disabled at runtime
+// via stdin commands and optionally restricted to a region of interest
+// defined by a mask PNG (white = watched, black = ignored).
+type MotionDetector struct {
+	mu        sync.Mutex
+	enabled   bool
+	threshold float64
+	minArea   int
+	mask      *image.Gray
+
+	reference []float64
+	blocksX   int
+	blocksY   int
+	refWidth  int
+	refHeight int
+
+	lastTrigger time.Time
+}
+
+// NewMotionDetector returns a detector that starts out disabled.
+func NewMotionDetector() *MotionDetector {
+	return &MotionDetector{}
+}
+
+// Enable turns motion detection on with the given per-block delta
+// threshold (0-255) and minimum number of changed blocks required to
+// trigger an event. It resets the reference frame so detection re-primes
+// cleanly rather than comparing against stale data.
+func (m *MotionDetector) Enable(threshold float64, minArea int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled = true
+	m.threshold = threshold
+	m.minArea = minArea
+	m.reference = nil
+}
+
+// Disable turns motion detection off.
+func (m *MotionDetector) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enabled = false
+}
+
+// Enabled reports whether motion detection is currently turned on.
+func (m *MotionDetector) Enabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.enabled
+}
+
+// Active reports whether motion was detected recently enough that the
+// capture loop should keep processing every frame rather than downshifting.
+func (m *MotionDetector) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.enabled && time.Since(m.lastTrigger) < motionActiveWindow
+}
+
+// LoadMask reads a PNG from path and uses it as the region of interest:
+// blocks whose majority of mask pixels are non-black are watched, the rest
+// are ignored.
+func (m *MotionDetector) LoadMask(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open motion mask: %w", err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return fmt.Errorf("failed to decode motion mask PNG: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mask = toGray(img)
+	return nil
+}
+
+// Process runs one SD frame through the detector. The first frame after
+// (re)enabling just primes the reference and never triggers. err is only
+// non-nil if the frame couldn't be decoded.
+func (m *MotionDetector) Process(sdJPEG []byte) (triggered bool, score float64, regions []motionRegion, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.enabled {
+		return false, 0, nil, nil
+	}
+
+	img, err := stdjpeg.Decode(bytes.NewReader(sdJPEG))
+	if err != nil {
+		return false, 0, nil, fmt.Errorf("failed to decode frame for motion detection: %w", err)
+	}
+
+	gray := toGray(img)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	blocksX := (width + motionBlockSize - 1) / motionBlockSize
+	blocksY := (height + motionBlockSize - 1) / motionBlockSize
+
+	if m.reference == nil || m.refWidth != width || m.refHeight != height {
+		m.reference = make([]float64, blocksX*blocksY)
+		m.refWidth, m.refHeight = width, height
+		m.blocksX, m.blocksY = blocksX, blocksY
+		for by := 0; by < blocksY; by++ {
+			for bx := 0; bx < blocksX; bx++ {
+				m.reference[by*blocksX+bx] = blockAverage(gray, bx, by, width, height)
+			}
+		}
+		return false, 0, nil, nil
+	}
+
+	changedBlocks := 0
+	watchedBlocks := 0
+	for by := 0; by < blocksY; by++ {
+		for bx := 0; bx < blocksX; bx++ {
+			if m.mask != nil && !m.blockInROI(bx, by, width, height) {
+				continue
+			}
+			watchedBlocks++
+
+			idx := by*blocksX + bx
+			avg := blockAverage(gray, bx, by, width, height)
+			delta := math.Abs(avg - m.reference[idx])
+			m.reference[idx] = m.reference[idx]*(1-motionEMAAlpha) + avg*motionEMAAlpha
+
+			if delta > m.threshold {
+				changedBlocks++
+				regions = append(regions, motionRegion{X: bx * motionBlockSize, Y: by * motionBlockSize, W: motionBlockSize, H: motionBlockSize})
+			}
+		}
+	}
+
+	if watchedBlocks > 0 {
+		score = float64(changedBlocks) / float64(watchedBlocks)
+	}
+	triggered = changedBlocks >= m.minArea
+	if triggered {
+		m.lastTrigger = time.Now()
+	}
+
+	return triggered, score, regions, nil
+}
+
+// blockInROI reports whether block (bx, by) falls mostly within the
+// masked region of interest (mask pixel value > 0 counts as watched).
+func (m *MotionDetector) blockInROI(bx, by, width, height int) bool {
+	maskBounds := m.mask.Bounds()
+	scaleX := float64(maskBounds.Dx()) / float64(width)
+	scaleY := float64(maskBounds.Dy()) / float64(height)
+
+	cx := int((float64(bx*motionBlockSize) + motionBlockSize/2) * scaleX)
+	cy := int((float64(by*motionBlockSize) + motionBlockSize/2) * scaleY)
+	return m.mask.GrayAt(cx+maskBounds.Min.X, cy+maskBounds.Min.Y).Y > 0
+}
+
+// toGray converts any image.Image to *image.Gray.
+func toGray(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+		}
+	}
+	return gray
+}
+
+// blockAverage returns the mean grayscale value of the block at (bx, by).
+func blockAverage(gray *image.Gray, bx, by, width, height int) float64 {
+	x0, y0 := bx*motionBlockSize, by*motionBlockSize
+	x1, y1 := x0+motionBlockSize, y0+motionBlockSize
+	if x1 > width {
+		x1 = width
+	}
+	if y1 > height {
+		y1 = height
+	}
+
+	var sum, count int
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			sum += int(gray.GrayAt(x, y).Y)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
+
+// saveMotionSnapshot writes the current full-resolution frame into the
+// rolling motion snapshot directory and prunes the oldest files beyond
+// motionSnapshotMaxFiles.
+func saveMotionSnapshot(frameBuffer *FrameBuffer) {
+	frame := frameBuffer.GetFull()
+	if frame == nil {
+		return
+	}
+
+	if err := os.MkdirAll(motionSnapshotDir, 0755); err != nil {
+		logJSON("warning", fmt.Sprintf("Failed to create motion snapshot directory: %v", err))
+		return
+	}
+
+	name := filepath.Join(motionSnapshotDir, fmt.Sprintf("motion_%d.jpeg", time.Now().UnixNano()))
+	if err := os.WriteFile(name, frame, 0644); err != nil {
+		logJSON("warning", fmt.Sprintf("Failed to write motion snapshot: %v", err))
+		return
+	}
+
+	pruneMotionSnapshots()
+}
+
+func pruneMotionSnapshots() {
+	entries, err := os.ReadDir(motionSnapshotDir)
+	if err != nil || len(entries) <= motionSnapshotMaxFiles {
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, e := range entries[:len(entries)-motionSnapshotMaxFiles] {
+		os.Remove(filepath.Join(motionSnapshotDir, e.Name()))
+	}
+}
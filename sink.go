@@ -0,0 +1,13 @@
+package main
+
+// Sink is a destination frames can be pushed to in addition to the local
+// /stream HTTP endpoint, such as an ffmpeg subprocess re-encoding to an
+// RTMP/HLS target.
+type Sink interface {
+	// Write pushes a single raw MJPEG frame to the sink. An error
+	// indicates the sink is no longer usable and should be recreated.
+	Write(frame []byte) error
+
+	// Close shuts the sink down, releasing any subprocess or connection.
+	Close() error
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// v4l2Source captures MJPEG frames from a local V4L2 device such as a USB
+// webcam. It is the original, and default, capture backend.
+type v4l2Source struct {
+	path      string
+	reqWidth  int
+	reqHeight int
+
+	dev    *device.Device
+	frames chan Frame
+}
+
+// newV4L2Source configures (but does not open) a V4L2 capture source for
+// the device at path, requesting width x height. A width or height of 0
+// means auto-detect the highest-resolution MJPEG mode the device offers.
+func newV4L2Source(path string, width, height int) *v4l2Source {
+	return &v4l2Source{path: path, reqWidth: width, reqHeight: height}
+}
+
+func (s *v4l2Source) Start(ctx context.Context) error {
+	dev, err := device.Open(s.path, device.WithBufferSize(4))
+	if err != nil {
+		return fmt.Errorf("failed to open v4l2 device %s: %w", s.path, err)
+	}
+	s.dev = dev
+	cam = dev // legacy INFO/CONTROLS/SET_CONTROL commands operate on the v4l2 device directly
+
+	logJSON("info", fmt.Sprintf("Device opened with 4 buffers: %s", s.path))
+
+	width, height := s.reqWidth, s.reqHeight
+	if width == 0 || height == 0 {
+		logJSON("info", "Auto-detecting best MJPEG resolution")
+		width, height, err = getBestMJPEGResolution(dev)
+		if err != nil {
+			dev.Close()
+			return fmt.Errorf("failed to detect MJPEG resolution: %w", err)
+		}
+		logJSON("info", fmt.Sprintf("Auto-detected resolution: %dx%d", width, height))
+	} else {
+		logJSON("info", fmt.Sprintf("Using specified resolution: %dx%d", width, height))
+	}
+
+	if err := dev.SetPixFormat(v4l2.PixFormat{
+		Width:       uint32(width),
+		Height:      uint32(height),
+		PixelFormat: v4l2.PixelFmtMJPEG,
+		Field:       v4l2.FieldNone,
+	}); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to set pixel format: %w", err)
+	}
+
+	pixFmt, err := dev.GetPixFormat()
+	if err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to get pixel format: %w", err)
+	}
+	logJSON("info", fmt.Sprintf("Capture format: %dx%d %s", pixFmt.Width, pixFmt.Height, pixFmt.PixelFormat))
+
+	rawFrames := dev.GetFrames()
+	if err := dev.Start(ctx); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to start stream: %w", err)
+	}
+	logJSON("info", "Stream started successfully")
+
+	s.frames = make(chan Frame)
+	go func() {
+		defer close(s.frames)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case frame, ok := <-rawFrames:
+				if !ok {
+					logJSON("error", "Frame channel closed - USB device disconnected")
+					os.Exit(ExitCodeUSBError)
+				}
+				if frame == nil {
+					continue
+				}
+				s.frames <- Frame{Data: frame.Data, Release: frame.Release}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *v4l2Source) Frames() <-chan Frame {
+	return s.frames
+}
+
+func (s *v4l2Source) Close() error {
+	if s.dev != nil {
+		s.dev.Close()
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// handleWebRTC negotiates a WebRTC peer connection for sub-200ms-latency
+// playback in the browser. The client POSTs an SDP offer and opens a
+// "frames" data channel; once it's open, frames are pushed over it as they
+// become available, same as /ws and /stream.
+//
+// A real H.264 video track (via V4L2 M2M hardware encode) would let
+// browsers use their native decoder instead of re-decoding JPEGs on the
+// data channel, but no such encoder is wired up yet, so this only offers
+// the data channel path.
+func handleWebRTC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var offer webrtc.SessionDescription
+	if err := json.NewDecoder(r.Body).Decode(&offer); err != nil {
+		http.Error(w, "invalid SDP offer", http.StatusBadRequest)
+		return
+	}
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{
+		ICEServers: []webrtc.ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}},
+	})
+	if err != nil {
+		logJSON("error", fmt.Sprintf("Failed to create WebRTC peer connection: %v", err))
+		http.Error(w, "failed to create peer connection", http.StatusInternalServerError)
+		return
+	}
+
+	// negotiationOK is flipped to true only once the SDP answer has been
+	// sent; any earlier return means negotiation failed and pc must be
+	// closed here, or it (and its ICE agent) leaks.
+	negotiationOK := false
+	defer func() {
+		if !negotiationOK {
+			pc.Close()
+		}
+	}()
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		logJSON("debug", fmt.Sprintf("WebRTC connection state: %s", state))
+		if state == webrtc.PeerConnectionStateFailed || state == webrtc.PeerConnectionStateClosed {
+			pc.Close()
+		}
+	})
+
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		dc.OnOpen(func() {
+			id, frames := broadcaster.Subscribe()
+			logJSON("debug", fmt.Sprintf("WebRTC client connected (total clients: %d)", broadcaster.Count()))
+
+			go func() {
+				defer broadcaster.Unsubscribe(id)
+				defer logJSON("debug", fmt.Sprintf("WebRTC client disconnected (remaining clients: %d)", broadcaster.Count()))
+
+				for frame := range frames {
+					if err := dc.Send(frame); err != nil {
+						return
+					}
+				}
+			}()
+		})
+	})
+
+	if err := pc.SetRemoteDescription(offer); err != nil {
+		logJSON("error", fmt.Sprintf("Failed to set WebRTC remote description: %v", err))
+		http.Error(w, "failed to set remote description", http.StatusInternalServerError)
+		return
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		logJSON("error", fmt.Sprintf("Failed to create WebRTC answer: %v", err))
+		http.Error(w, "failed to create answer", http.StatusInternalServerError)
+		return
+	}
+
+	gatherComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		logJSON("error", fmt.Sprintf("Failed to set WebRTC local description: %v", err))
+		http.Error(w, "failed to set local description", http.StatusInternalServerError)
+		return
+	}
+	<-gatherComplete
+	negotiationOK = true
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pc.LocalDescription()); err != nil {
+		logJSON("warning", fmt.Sprintf("Failed to write WebRTC answer: %v", err))
+	}
+}